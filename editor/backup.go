@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const backupDirName = ".backups"
+
+type BackupInfo struct {
+	Name    string `json:"name"`
+	Time    int64  `json:"time"` // Unix nanoseconds, from the file name
+	Size    int64  `json:"size"`
+	ModTime string `json:"modTime"`
+}
+
+type RestoreRequest struct {
+	Path   string `json:"path"`
+	Backup string `json:"backup"`
+}
+
+// inBackupDir reports whether path has a .backups component. Those
+// directories belong to the editor and are never served or written directly.
+func inBackupDir(path string) bool {
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if part == backupDirName {
+			return true
+		}
+	}
+	return false
+}
+
+// backupDir returns the .backups directory that sits next to the given file.
+func backupDir(fullPath string) string {
+	return filepath.Join(filepath.Dir(fullPath), backupDirName)
+}
+
+// backupFile copies the current contents of fullPath into the sibling
+// .backups directory as <name>.bak.<unix nanoseconds> and prunes old copies.
+func backupFile(fullPath string) error {
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return err
+	}
+
+	dir := backupDir(fullPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// O_EXCL so an earlier backup is never overwritten; on a clash move to
+	// the next free stamp, which still sorts after the existing one
+	var f *os.File
+	var path string
+	for stamp := time.Now().UnixNano(); ; stamp++ {
+		path = filepath.Join(dir, fmt.Sprintf("%s.bak.%d", filepath.Base(fullPath), stamp))
+		f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if !os.IsExist(err) {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	return pruneBackups(fullPath, *maxBackups)
+}
+
+// listBackups returns the backups of fullPath, newest first.
+func listBackups(fullPath string) ([]BackupInfo, error) {
+	entries, err := os.ReadDir(backupDir(fullPath))
+	if os.IsNotExist(err) {
+		return []BackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := filepath.Base(fullPath) + ".bak."
+	backups := []BackupInfo{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		stamp, err := strconv.ParseInt(strings.TrimPrefix(entry.Name(), prefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, BackupInfo{
+			Name:    entry.Name(),
+			Time:    stamp,
+			Size:    info.Size(),
+			ModTime: info.ModTime().Format(time.RFC3339),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time > backups[j].Time
+	})
+
+	return backups, nil
+}
+
+// pruneBackups removes all but the newest keep backups of fullPath.
+func pruneBackups(fullPath string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	backups, err := listBackups(fullPath)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range backups[min(keep, len(backups)):] {
+		if err := os.Remove(filepath.Join(backupDir(fullPath), b.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func handleBackups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		http.Error(w, "Path required", http.StatusBadRequest)
+		return
+	}
+
+	// Security: prevent directory traversal and direct access to backups
+	if strings.Contains(path, "..") || inBackupDir(path) {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+
+	// Only allow XML files
+	if !strings.HasSuffix(path, ".xml") {
+		http.Error(w, "Only XML files allowed", http.StatusBadRequest)
+		return
+	}
+
+	backups, err := listBackups(filepath.Join(tipitakaDir, path))
+	if err != nil {
+		http.Error(w, "Failed to list backups: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backups)
+}
+
+func handleRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req RestoreRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Security: prevent directory traversal and direct access to backups
+	if strings.Contains(req.Path, "..") || inBackupDir(req.Path) || strings.ContainsAny(req.Backup, `/\`) {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+
+	// Only allow XML files, and only backups taken of that file
+	if !strings.HasSuffix(req.Path, ".xml") {
+		http.Error(w, "Only XML files allowed", http.StatusBadRequest)
+		return
+	}
+	if !strings.HasPrefix(req.Backup, filepath.Base(req.Path)+".bak.") {
+		http.Error(w, "Backup does not belong to file", http.StatusBadRequest)
+		return
+	}
+
+	fullPath := filepath.Join(tipitakaDir, req.Path)
+
+	content, err := os.ReadFile(filepath.Join(backupDir(fullPath), req.Backup))
+	if err != nil {
+		http.Error(w, "Backup not found", http.StatusNotFound)
+		return
+	}
+
+	// Keep the current version so a restore can itself be undone
+	if _, err := os.Stat(fullPath); err == nil {
+		if err := backupFile(fullPath); err != nil {
+			http.Error(w, "Failed to back up file: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := os.WriteFile(fullPath, content, 0644); err != nil {
+		http.Error(w, "Failed to restore file: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "restored", "path": req.Path, "backup": req.Backup})
+}
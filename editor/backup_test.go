@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readBackups returns the contents of every backup of fullPath, newest first.
+func readBackups(t *testing.T, fullPath string) []string {
+	t.Helper()
+	backups, err := listBackups(fullPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contents []string
+	for _, b := range backups {
+		content, err := os.ReadFile(filepath.Join(backupDir(fullPath), b.Name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, string(content))
+	}
+	return contents
+}
+
+func setMaxBackups(t *testing.T, n int) {
+	t.Helper()
+	old := *maxBackups
+	*maxBackups = n
+	t.Cleanup(func() { *maxBackups = old })
+}
+
+func TestBackupFileWritesToBackupsDir(t *testing.T) {
+	root := useTempTipitaka(t)
+	writeTestFile(t, root, "my/a.xml", "original")
+	fullPath := filepath.Join(root, "my", "a.xml")
+
+	if err := backupFile(fullPath); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(root, "my", backupDirName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d backups, want 1", len(entries))
+	}
+	if got := readBackups(t, fullPath); got[0] != "original" {
+		t.Errorf("backup content = %q, want %q", got[0], "original")
+	}
+}
+
+func TestSaveFileKeepsEveryVersion(t *testing.T) {
+	root := useTempTipitaka(t)
+	writeTestFile(t, root, "my/a.xml", "original")
+
+	// Back-to-back saves land in the same second and must not clobber
+	// each other's backups
+	for _, v := range []string{"v1", "v2", "v3"} {
+		body := fmt.Sprintf(`{"path":"my/a.xml","content":%q}`, v)
+		if rec := serve(handleFile, http.MethodPut, "/api/file", body); rec.Code != http.StatusOK {
+			t.Fatalf("save %s: status = %d: %s", v, rec.Code, rec.Body)
+		}
+	}
+
+	got := readBackups(t, filepath.Join(root, "my", "a.xml"))
+	want := []string{"v2", "v1", "original"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("backups = %q, want %q", got, want)
+	}
+}
+
+func TestPruneBackupsKeepsNewest(t *testing.T) {
+	root := useTempTipitaka(t)
+	setMaxBackups(t, 3)
+	fullPath := filepath.Join(root, "my", "a.xml")
+
+	for i := range 6 {
+		writeTestFile(t, root, "my/a.xml", fmt.Sprintf("v%d", i))
+		if err := backupFile(fullPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := readBackups(t, fullPath)
+	want := []string{"v5", "v4", "v3"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("backups = %q, want %q", got, want)
+	}
+}
+
+func TestPruneBackupsLeavesOtherFiles(t *testing.T) {
+	root := useTempTipitaka(t)
+	setMaxBackups(t, 1)
+	writeTestFile(t, root, "my/a.xml", "a")
+	writeTestFile(t, root, "my/b.xml", "b")
+
+	for _, name := range []string{"b.xml", "a.xml", "a.xml"} {
+		if err := backupFile(filepath.Join(root, "my", name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := readBackups(t, filepath.Join(root, "my", "b.xml")); len(got) != 1 {
+		t.Errorf("b.xml has %d backups, want 1", len(got))
+	}
+	if got := readBackups(t, filepath.Join(root, "my", "a.xml")); len(got) != 1 {
+		t.Errorf("a.xml has %d backups, want 1", len(got))
+	}
+}
+
+func TestRestoreRejectsOtherFilesBackup(t *testing.T) {
+	root := useTempTipitaka(t)
+	writeTestFile(t, root, "my/a.xml", "a")
+	writeTestFile(t, root, "my/b.xml", "b")
+	if err := backupFile(filepath.Join(root, "my", "b.xml")); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := listBackups(filepath.Join(root, "my", "b.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := fmt.Sprintf(`{"path":"my/a.xml","backup":%q}`, backups[0].Name)
+	rec := serve(handleRestore, http.MethodPost, "/api/restore", body)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, "my", "a.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "a" {
+		t.Errorf("a.xml = %q, want it unchanged", content)
+	}
+}
+
+func TestRestoreBacksUpCurrentVersion(t *testing.T) {
+	root := useTempTipitaka(t)
+	fullPath := filepath.Join(root, "my", "a.xml")
+	writeTestFile(t, root, "my/a.xml", "old")
+	if err := backupFile(fullPath); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := listBackups(fullPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, root, "my/a.xml", "current")
+
+	body := fmt.Sprintf(`{"path":"my/a.xml","backup":%q}`, backups[0].Name)
+	rec := serve(handleRestore, http.MethodPost, "/api/restore", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "old" {
+		t.Errorf("restored content = %q, want %q", content, "old")
+	}
+
+	got := readBackups(t, fullPath)
+	want := []string{"current", "old"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("backups = %q, want %q", got, want)
+	}
+}
+
+func TestInBackupDir(t *testing.T) {
+	tests := map[string]bool{
+		"my/.backups/a.xml":  true,
+		".backups/a.xml":     true,
+		"my/.backups":        true,
+		`my\.backups\a.xml`:  true,
+		"my/a.xml":           false,
+		"my/.backups.xml":    false,
+		"my/x.backups/a.xml": false,
+		"my/.backupsx/a.xml": false,
+	}
+	for path, want := range tests {
+		if got := inBackupDir(path); got != want {
+			t.Errorf("inBackupDir(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestHandlersRejectBackupPaths(t *testing.T) {
+	root := useTempTipitaka(t)
+	writeTestFile(t, root, "my/.backups/x.xml", "backup")
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		target  string
+		body    string
+	}{
+		{"list", listFiles, http.MethodGet, "/api/files?dir=my/.backups", ""},
+		{"get", handleFile, http.MethodGet, "/api/file?path=my/.backups/x.xml", ""},
+		{"save", handleFile, http.MethodPut, "/api/file", `{"path":"my/.backups/x.xml","content":"new"}`},
+		{"backups", handleBackups, http.MethodGet, "/api/backups?path=my/.backups/x.xml", ""},
+		{"restore", handleRestore, http.MethodPost, "/api/restore", `{"path":"my/.backups/x.xml","backup":"x.xml.bak.1"}`},
+		{"diff", handleDiff, http.MethodPost, "/api/diff", `{"path":"my/.backups/x.xml","content":"new"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.handler, tt.method, tt.target, tt.body)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+		})
+	}
+
+	if got := readTestFile(t, root, "my/.backups/x.xml"); got != "backup" {
+		t.Errorf("backup modified: %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "my", ".backups", ".backups")); err == nil {
+		t.Error("nested .backups directory created")
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// lines joins words into newline-terminated lines.
+func lines(words string) string {
+	return strings.Join(strings.Fields(words), "\n") + "\n"
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name           string
+		old, new       string
+		want           string
+		added, removed int
+	}{
+		{
+			name: "insertion at start",
+			old:  lines("a b c"),
+			new:  lines("new a b c"),
+			want: `--- a/f.xml
++++ b/f.xml
+@@ -1,3 +1,4 @@
++new
+ a
+ b
+ c
+`,
+			added: 1,
+		},
+		{
+			name: "deletion at end",
+			old:  lines("a b c d"),
+			new:  lines("a b c"),
+			want: `--- a/f.xml
++++ b/f.xml
+@@ -1,4 +1,3 @@
+ a
+ b
+ c
+-d
+`,
+			removed: 1,
+		},
+		{
+			name: "nearby changes merge into one hunk",
+			old:  lines("1 2 3 4 5 6 7 8 9 10"),
+			new:  lines("1 X 3 4 5 6 7 Y 9 10"),
+			want: `--- a/f.xml
++++ b/f.xml
+@@ -1,10 +1,10 @@
+ 1
+-2
++X
+ 3
+ 4
+ 5
+ 6
+ 7
+-8
++Y
+ 9
+ 10
+`,
+			added:   2,
+			removed: 2,
+		},
+		{
+			name: "distant changes stay separate",
+			old:  lines("1 2 3 4 5 6 7 8 9 10 11 12"),
+			new:  lines("1 X 3 4 5 6 7 8 9 10 Y 12"),
+			want: `--- a/f.xml
++++ b/f.xml
+@@ -1,5 +1,5 @@
+ 1
+-2
++X
+ 3
+ 4
+ 5
+@@ -8,5 +8,5 @@
+ 8
+ 9
+ 10
+-11
++Y
+ 12
+`,
+			added:   2,
+			removed: 2,
+		},
+		{
+			name: "no change",
+			old:  lines("a b c"),
+			new:  lines("a b c"),
+			want: "",
+		},
+		{
+			name: "final newline removed",
+			old:  "a\nx\n",
+			new:  "a\nx",
+			want: `--- a/f.xml
++++ b/f.xml
+@@ -1,2 +1,2 @@
+ a
+-x
++x
+\ No newline at end of file
+`,
+			added:   1,
+			removed: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, added, removed := unifiedDiff("f.xml", tt.old, tt.new)
+			if got != tt.want {
+				t.Errorf("diff =\n%s\nwant\n%s", got, tt.want)
+			}
+			if added != tt.added || removed != tt.removed {
+				t.Errorf("counts = +%d -%d, want +%d -%d", added, removed, tt.added, tt.removed)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"log"
@@ -9,10 +10,20 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 )
 
-const tipitakaDir = "../public/tipitaka"
+// tipitakaDir is the root every handler serves from; tests point it at a
+// temporary directory.
+var tipitakaDir = "../public/tipitaka"
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+var maxBackups = flag.Int("backups", 10, "number of backups to keep per file (0 or less keeps all)")
 
 type FileInfo struct {
 	Name  string `json:"name"`
@@ -20,12 +31,21 @@ type FileInfo struct {
 	IsDir bool   `json:"isDir"`
 }
 
+type FileListResponse struct {
+	Files    []FileInfo `json:"files"`
+	Total    int        `json:"total"`
+	Page     int        `json:"page"`
+	PageSize int        `json:"pageSize"`
+}
+
 type FileContent struct {
 	Path    string `json:"path"`
 	Content string `json:"content"`
 }
 
 func main() {
+	flag.Parse()
+
 	// Serve static files (HTML, CSS, JS)
 	http.HandleFunc("/", serveIndex)
 	http.HandleFunc("/style.css", serveCSS)
@@ -33,6 +53,11 @@ func main() {
 	// API endpoints
 	http.HandleFunc("/api/files", listFiles)
 	http.HandleFunc("/api/file", handleFile)
+	http.HandleFunc("/api/file/new", createFile)
+	http.HandleFunc("/api/backups", handleBackups)
+	http.HandleFunc("/api/restore", handleRestore)
+	http.HandleFunc("/api/search-replace", handleSearchReplace)
+	http.HandleFunc("/api/diff", handleDiff)
 
 	port := ":9000"
 	fmt.Printf("Pali XML Editor running at http://localhost%s\n", port)
@@ -63,12 +88,26 @@ func listFiles(w http.ResponseWriter, r *http.Request) {
 		dir = ""
 	}
 
-	// Security: prevent directory traversal
-	if strings.Contains(dir, "..") {
+	// Security: prevent directory traversal and direct access to backups
+	if strings.Contains(dir, "..") || inBackupDir(dir) {
 		http.Error(w, "Invalid path", http.StatusBadRequest)
 		return
 	}
 
+	page, err := queryInt(r, "page", 1)
+	if err != nil || page < 1 {
+		http.Error(w, "Invalid page", http.StatusBadRequest)
+		return
+	}
+
+	pageSize, err := queryInt(r, "pageSize", defaultPageSize)
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		http.Error(w, "Invalid pageSize", http.StatusBadRequest)
+		return
+	}
+
+	filter := strings.ToLower(r.URL.Query().Get("filter"))
+
 	fullPath := filepath.Join(tipitakaDir, dir)
 
 	entries, err := os.ReadDir(fullPath)
@@ -77,8 +116,16 @@ func listFiles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var files []FileInfo
+	files := []FileInfo{}
 	for _, entry := range entries {
+		if entry.Name() == backupDirName {
+			continue
+		}
+
+		if filter != "" && !strings.Contains(strings.ToLower(entry.Name()), filter) {
+			continue
+		}
+
 		// Only show directories and XML files
 		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".xml") {
 			files = append(files, FileInfo{
@@ -97,8 +144,30 @@ func listFiles(w http.ResponseWriter, r *http.Request) {
 		return files[i].Name < files[j].Name
 	})
 
+	// Clamp before multiplying so a huge page number can't overflow
+	total := len(files)
+	start := total
+	if page-1 <= total/pageSize {
+		start = min((page-1)*pageSize, total)
+	}
+	end := min(start+pageSize, total)
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(files)
+	json.NewEncoder(w).Encode(FileListResponse{
+		Files:    files[start:end],
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// queryInt reads an integer query parameter, returning def when it is absent.
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	return strconv.Atoi(v)
 }
 
 func handleFile(w http.ResponseWriter, r *http.Request) {
@@ -119,8 +188,8 @@ func getFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Security: prevent directory traversal
-	if strings.Contains(path, "..") {
+	// Security: prevent directory traversal and direct access to backups
+	if strings.Contains(path, "..") || inBackupDir(path) {
 		http.Error(w, "Invalid path", http.StatusBadRequest)
 		return
 	}
@@ -160,8 +229,8 @@ func saveFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Security: prevent directory traversal
-	if strings.Contains(fc.Path, "..") {
+	// Security: prevent directory traversal and direct access to backups
+	if strings.Contains(fc.Path, "..") || inBackupDir(fc.Path) {
 		http.Error(w, "Invalid path", http.StatusBadRequest)
 		return
 	}
@@ -180,6 +249,11 @@ func saveFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := backupFile(fullPath); err != nil {
+		http.Error(w, "Failed to back up file: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	if err := os.WriteFile(fullPath, []byte(fc.Content), 0644); err != nil {
 		http.Error(w, "Failed to save file: "+err.Error(), http.StatusInternalServerError)
 		return
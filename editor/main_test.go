@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useTempTipitaka points the handlers at a fresh temporary directory and
+// returns its path.
+func useTempTipitaka(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	old := tipitakaDir
+	tipitakaDir = dir
+	t.Cleanup(func() { tipitakaDir = old })
+	return dir
+}
+
+// writeTestFile creates root/rel with the given content, making parent
+// directories as needed.
+func writeTestFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serve runs handler against a single request and returns the recorder.
+func serve(handler http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+// listNames fetches a directory listing and returns it with the entry names.
+func listNames(t *testing.T, query string) (FileListResponse, []string) {
+	t.Helper()
+	rec := serve(listFiles, http.MethodGet, "/api/files?"+query, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var resp FileListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, f := range resp.Files {
+		names = append(names, f.Name)
+	}
+	return resp, names
+}
+
+// listingTree creates two directories and five XML files under my/.
+func listingTree(t *testing.T) {
+	t.Helper()
+	root := useTempTipitaka(t)
+	for _, name := range []string{"a1m.xml", "a2m.xml", "d1m.xml", "D2A.xml", "s1m.xml"} {
+		writeTestFile(t, root, "my/"+name, "<body/>")
+	}
+	writeTestFile(t, root, "my/notes.txt", "")
+	writeTestFile(t, root, "my/dir-b/x.xml", "<body/>")
+	writeTestFile(t, root, "my/Dir-a/x.xml", "<body/>")
+	writeTestFile(t, root, "my/.backups/a1m.xml.bak.1", "")
+}
+
+func TestListFilesPaging(t *testing.T) {
+	listingTree(t)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"dir=my", "[Dir-a dir-b D2A.xml a1m.xml a2m.xml d1m.xml s1m.xml]"},
+		{"dir=my&page=1&pageSize=3", "[Dir-a dir-b D2A.xml]"},
+		{"dir=my&page=3&pageSize=3", "[s1m.xml]"},
+		{"dir=my&page=4&pageSize=3", "[]"},
+		{"dir=my&page=99&pageSize=3", "[]"},
+		{"dir=my&page=9223372036854775807&pageSize=1000", "[]"},
+		{"dir=my&page=9223372036854775807&pageSize=1", "[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			resp, names := listNames(t, tt.query)
+			if got := fmt.Sprint(names); got != tt.want {
+				t.Errorf("files = %s, want %s", got, tt.want)
+			}
+			if resp.Total != 7 {
+				t.Errorf("total = %d, want 7", resp.Total)
+			}
+			if resp.Files == nil {
+				t.Error("files is null, want []")
+			}
+		})
+	}
+}
+
+func TestListFilesFilter(t *testing.T) {
+	listingTree(t)
+
+	resp, names := listNames(t, "dir=my&filter=D")
+	if got, want := fmt.Sprint(names), "[Dir-a dir-b D2A.xml d1m.xml]"; got != want {
+		t.Errorf("files = %s, want %s", got, want)
+	}
+	if resp.Total != 4 {
+		t.Errorf("total = %d, want 4", resp.Total)
+	}
+
+	_, names = listNames(t, "dir=my&filter=2a")
+	if got, want := fmt.Sprint(names), "[D2A.xml]"; got != want {
+		t.Errorf("files = %s, want %s", got, want)
+	}
+}
+
+func TestListFilesRejectsBadPaging(t *testing.T) {
+	listingTree(t)
+
+	for _, query := range []string{
+		"dir=my&page=0",
+		"dir=my&page=-1",
+		"dir=my&page=x",
+		"dir=my&page=9223372036854775808",
+		"dir=my&pageSize=0",
+		fmt.Sprintf("dir=my&pageSize=%d", maxPageSize+1),
+	} {
+		rec := serve(listFiles, http.MethodGet, "/api/files?"+query, "")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", query, rec.Code, http.StatusBadRequest)
+		}
+	}
+
+	rec := serve(listFiles, http.MethodGet, fmt.Sprintf("/api/files?dir=my&pageSize=%d", maxPageSize), "")
+	if rec.Code != http.StatusOK {
+		t.Errorf("pageSize=maxPageSize: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Skeleton documents for new files, keyed by template name
+var fileTemplates = map[string]string{
+	"tipitaka": `<?xml version="1.0"?>
+<body>
+  <h> </h>
+  <ha>
+    <han> </han>
+    <h0>
+      <h0n> </h0n>
+      <h1>
+        <h1n> </h1n>
+        <h2>
+          <h2n> </h2n>
+          <h3>
+            <h3n> </h3n>
+            <h4>
+              <h4n> </h4n>
+              <p> </p>
+            </h4>
+          </h3>
+        </h2>
+      </h1>
+    </h0>
+  </ha>
+</body>
+`,
+	"dictionary": `<?xml version="1.0"?>
+<xml>
+</xml>
+`,
+}
+
+type NewFileRequest struct {
+	Path     string `json:"path"`
+	Template string `json:"template"`
+}
+
+func createFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req NewFileRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Path == "" {
+		http.Error(w, "Path required", http.StatusBadRequest)
+		return
+	}
+
+	// Security: prevent directory traversal and direct access to backups
+	if strings.Contains(req.Path, "..") || inBackupDir(req.Path) {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+
+	// Only allow XML files
+	if !strings.HasSuffix(req.Path, ".xml") {
+		http.Error(w, "Only XML files allowed", http.StatusBadRequest)
+		return
+	}
+
+	if req.Template == "" {
+		req.Template = "tipitaka"
+	}
+	content, ok := fileTemplates[req.Template]
+	if !ok {
+		http.Error(w, "Unknown template: "+req.Template, http.StatusBadRequest)
+		return
+	}
+
+	fullPath := filepath.Join(tipitakaDir, req.Path)
+
+	createdDirs, err := mkdirAll(filepath.Dir(fullPath))
+	if err != nil {
+		removeDirs(createdDirs)
+		http.Error(w, "Failed to create directory: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// O_EXCL so an existing file is never overwritten
+	f, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		http.Error(w, "File already exists", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		removeDirs(createdDirs)
+		http.Error(w, "Failed to create file: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = f.WriteString(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		// Don't leave a partial file behind to block the next attempt
+		os.Remove(fullPath)
+		removeDirs(createdDirs)
+		http.Error(w, "Failed to write file: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"status": "created", "path": req.Path})
+}
+
+// mkdirAll is os.MkdirAll that also returns the directories it had to
+// create, deepest first, so a failed request can remove them again.
+func mkdirAll(dir string) ([]string, error) {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		}
+		missing = append(missing, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	return missing, os.MkdirAll(dir, 0755)
+}
+
+// removeDirs removes the given directories in order, leaving any that are
+// not empty (another request may have put files there meanwhile).
+func removeDirs(dirs []string) {
+	for _, d := range dirs {
+		os.Remove(d)
+	}
+}
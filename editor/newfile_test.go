@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateFile(t *testing.T) {
+	root := useTempTipitaka(t)
+
+	rec := serve(createFile, http.MethodPost, "/api/file/new", `{"path":"si/new.xml"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, "si", "new.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != fileTemplates["tipitaka"] {
+		t.Errorf("content = %q, want tipitaka template", content)
+	}
+	if err := xml.Unmarshal(content, new(struct{})); err != nil {
+		t.Errorf("template is not well-formed XML: %v", err)
+	}
+}
+
+func TestCreateFileDictionaryTemplate(t *testing.T) {
+	root := useTempTipitaka(t)
+
+	rec := serve(createFile, http.MethodPost, "/api/file/new", `{"path":"d.xml","template":"dictionary"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, "d.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != fileTemplates["dictionary"] {
+		t.Errorf("content = %q, want dictionary template", content)
+	}
+}
+
+func TestCreateFileRejectsDuplicate(t *testing.T) {
+	root := useTempTipitaka(t)
+	writeTestFile(t, root, "my/a.xml", "<body>keep</body>")
+
+	rec := serve(createFile, http.MethodPost, "/api/file/new", `{"path":"my/a.xml"}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, "my", "a.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "<body>keep</body>" {
+		t.Errorf("existing file was modified: %q", content)
+	}
+}
+
+func TestCreateFileRejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"traversal", `{"path":"../outside.xml"}`},
+		{"nested traversal", `{"path":"my/../../outside.xml"}`},
+		{"backups dir", `{"path":"my/.backups/x.xml"}`},
+		{"top-level backups dir", `{"path":".backups/x.xml"}`},
+		{"not xml", `{"path":"my/a.txt"}`},
+		{"empty path", `{"path":""}`},
+		{"unknown template", `{"path":"my/a.xml","template":"nope"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := useTempTipitaka(t)
+
+			rec := serve(createFile, http.MethodPost, "/api/file/new", tt.body)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+
+			if _, err := os.Stat(filepath.Join(filepath.Dir(root), "outside.xml")); err == nil {
+				t.Error("file created outside the tipitaka directory")
+			}
+			entries, err := os.ReadDir(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("unexpected entries created: %v", entries)
+			}
+		})
+	}
+}
+
+func TestCreateFileRemovesNewDirsOnFailure(t *testing.T) {
+	root := useTempTipitaka(t)
+	writeTestFile(t, root, "my/a.xml", "<body/>")
+
+	// A file name over the usual 255-byte limit fails after the
+	// directories have been created
+	long := strings.Repeat("x", 300) + ".xml"
+	body := fmt.Sprintf(`{"path":"my/new/deeper/%s"}`, long)
+	rec := serve(createFile, http.MethodPost, "/api/file/new", body)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "my", "new")); !os.IsNotExist(err) {
+		t.Errorf("my/new left behind after failed create (err = %v)", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "my", "a.xml")); err != nil {
+		t.Errorf("existing directory contents touched: %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type ReplaceRequest struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+	Regex       bool   `json:"regex"`
+	DryRun      bool   `json:"dryRun"`
+	Glob        string `json:"glob"`
+}
+
+type ReplaceFileResult struct {
+	Path     string `json:"path"`
+	Matches  int    `json:"matches"`
+	Replaced int    `json:"replaced"`
+}
+
+type ReplaceResponse struct {
+	Files         []ReplaceFileResult `json:"files"`
+	TotalMatches  int                 `json:"totalMatches"`
+	TotalReplaced int                 `json:"totalReplaced"`
+	DryRun        bool                `json:"dryRun"`
+	Failed        string              `json:"failed,omitempty"`
+	Error         string              `json:"error,omitempty"`
+}
+
+// matchesGlob reports whether the relative path rel is selected by glob.
+// Patterns without a separator are matched against the file name alone.
+func matchesGlob(glob, rel string) bool {
+	if glob == "" {
+		return true
+	}
+	target := filepath.ToSlash(rel)
+	if !strings.Contains(glob, "/") {
+		target = filepath.Base(rel)
+	}
+	ok, _ := filepath.Match(glob, target)
+	return ok
+}
+
+// replaceInFiles walks root and applies re to every matching XML file,
+// writing the results back unless dryRun is set. All files are read and
+// rewritten in memory before any is written, so a read error changes
+// nothing. On error the partial response is returned with Failed set to the
+// offending file; files with Replaced > 0 have already been changed.
+func replaceInFiles(root string, re *regexp.Regexp, replacement string, literal bool, glob string, dryRun bool) (ReplaceResponse, error) {
+	resp := ReplaceResponse{Files: []ReplaceFileResult{}, DryRun: dryRun}
+
+	type pendingFile struct {
+		path    string
+		updated []byte
+	}
+	var pending []pendingFile
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == backupDirName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".xml") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if !matchesGlob(glob, rel) {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			resp.Failed = filepath.ToSlash(rel)
+			return err
+		}
+
+		matches := len(re.FindAllIndex(content, -1))
+		if matches == 0 {
+			return nil
+		}
+
+		resp.Files = append(resp.Files, ReplaceFileResult{Path: filepath.ToSlash(rel), Matches: matches})
+		resp.TotalMatches += matches
+
+		if !dryRun {
+			var updated []byte
+			if literal {
+				updated = re.ReplaceAllLiteral(content, []byte(replacement))
+			} else {
+				updated = re.ReplaceAll(content, []byte(replacement))
+			}
+			pending = append(pending, pendingFile{path: path, updated: updated})
+		}
+		return nil
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	for i, p := range pending {
+		if err := backupFile(p.path); err != nil {
+			resp.Failed = resp.Files[i].Path
+			return resp, err
+		}
+		if err := os.WriteFile(p.path, p.updated, 0644); err != nil {
+			resp.Failed = resp.Files[i].Path
+			return resp, err
+		}
+		resp.Files[i].Replaced = resp.Files[i].Matches
+		resp.TotalReplaced += resp.Files[i].Matches
+	}
+
+	return resp, nil
+}
+
+func handleSearchReplace(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req ReplaceRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Pattern == "" {
+		http.Error(w, "Pattern required", http.StatusBadRequest)
+		return
+	}
+
+	// Security: prevent directory traversal
+	if strings.Contains(req.Glob, "..") {
+		http.Error(w, "Invalid glob", http.StatusBadRequest)
+		return
+	}
+	if _, err := filepath.Match(req.Glob, ""); err != nil {
+		http.Error(w, "Invalid glob: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	expr := req.Pattern
+	if !req.Regex {
+		expr = regexp.QuoteMeta(expr)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		http.Error(w, "Invalid pattern: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// A pattern that matches nothing would insert the replacement between
+	// every character of every file
+	if re.MatchString("") {
+		http.Error(w, "Pattern must not match the empty string", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := replaceInFiles(tipitakaDir, re, req.Replacement, !req.Regex, req.Glob, req.DryRun)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		// Report the partial result so the caller knows what was changed
+		resp.Error = "Failed to replace: " + err.Error()
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
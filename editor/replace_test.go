@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// replaceTree builds a small tipitaka tree for the replace tests.
+func replaceTree(t *testing.T) string {
+	t.Helper()
+	root := useTempTipitaka(t)
+	writeTestFile(t, root, "my/a.xml", "<p>dhamma dhamma</p>")
+	writeTestFile(t, root, "my/b.xml", "<p>dhamma a.b</p>")
+	writeTestFile(t, root, "th/c.xml", "<p>dhamma</p>")
+	writeTestFile(t, root, "my/notes.txt", "dhamma")
+	writeTestFile(t, root, "my/.backups/a.xml.bak.1", "dhamma")
+	return root
+}
+
+func readTestFile(t *testing.T, root, rel string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(root, rel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func fileCounts(resp ReplaceResponse) map[string][2]int {
+	counts := map[string][2]int{}
+	for _, f := range resp.Files {
+		counts[f.Path] = [2]int{f.Matches, f.Replaced}
+	}
+	return counts
+}
+
+func TestReplaceInFilesDryRun(t *testing.T) {
+	root := replaceTree(t)
+
+	resp, err := replaceInFiles(root, regexp.MustCompile("dhamma"), "x", true, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][2]int{"my/a.xml": {2, 0}, "my/b.xml": {1, 0}, "th/c.xml": {1, 0}}
+	if got := fileCounts(resp); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+	if resp.TotalMatches != 4 || resp.TotalReplaced != 0 {
+		t.Errorf("totals = %d/%d, want 4/0", resp.TotalMatches, resp.TotalReplaced)
+	}
+	if got := readTestFile(t, root, "my/a.xml"); got != "<p>dhamma dhamma</p>" {
+		t.Errorf("dry run modified a.xml: %q", got)
+	}
+}
+
+func TestReplaceInFilesLiteral(t *testing.T) {
+	root := replaceTree(t)
+
+	re := regexp.MustCompile(regexp.QuoteMeta("a.b"))
+	resp, err := replaceInFiles(root, re, "$1", true, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.TotalMatches != 1 || resp.TotalReplaced != 1 {
+		t.Errorf("totals = %d/%d, want 1/1", resp.TotalMatches, resp.TotalReplaced)
+	}
+	if got := readTestFile(t, root, "my/b.xml"); got != "<p>dhamma $1</p>" {
+		t.Errorf("b.xml = %q", got)
+	}
+	if got := readTestFile(t, root, "my/a.xml"); got != "<p>dhamma dhamma</p>" {
+		t.Errorf("a.xml modified: %q", got)
+	}
+}
+
+func TestReplaceInFilesRegexGroup(t *testing.T) {
+	root := replaceTree(t)
+
+	resp, err := replaceInFiles(root, regexp.MustCompile(`dh(a)mma`), "dh${1}${1}mma", false, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.TotalReplaced != 4 {
+		t.Errorf("replaced = %d, want 4", resp.TotalReplaced)
+	}
+	if got := readTestFile(t, root, "my/a.xml"); got != "<p>dhaamma dhaamma</p>" {
+		t.Errorf("a.xml = %q", got)
+	}
+}
+
+func TestReplaceInFilesGlob(t *testing.T) {
+	tests := []struct {
+		glob string
+		want []string
+	}{
+		{"a.xml", []string{"my/a.xml"}},
+		{"th/*.xml", []string{"th/c.xml"}},
+		{"my/*", []string{"my/a.xml", "my/b.xml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.glob, func(t *testing.T) {
+			root := replaceTree(t)
+
+			resp, err := replaceInFiles(root, regexp.MustCompile("dhamma"), "x", true, tt.glob, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, f := range resp.Files {
+				got = append(got, f.Path)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("files = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("files = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReplaceInFilesSkipsBackupsAndNonXML(t *testing.T) {
+	root := replaceTree(t)
+
+	if _, err := replaceInFiles(root, regexp.MustCompile("dhamma"), "x", true, "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readTestFile(t, root, "my/notes.txt"); got != "dhamma" {
+		t.Errorf("notes.txt modified: %q", got)
+	}
+	if got := readTestFile(t, root, "my/.backups/a.xml.bak.1"); got != "dhamma" {
+		t.Errorf("existing backup modified: %q", got)
+	}
+	// The replacement itself backs up the original
+	if got := readBackups(t, filepath.Join(root, "my", "a.xml")); len(got) != 2 || got[0] != "<p>dhamma dhamma</p>" {
+		t.Errorf("a.xml backups = %q", got)
+	}
+}
+
+func TestReplaceInFilesReadErrorChangesNothing(t *testing.T) {
+	root := replaceTree(t)
+	if err := os.Symlink(filepath.Join(root, "missing"), filepath.Join(root, "th", "z.xml")); err != nil {
+		t.Skip("symlinks unavailable:", err)
+	}
+
+	resp, err := replaceInFiles(root, regexp.MustCompile("dhamma"), "x", true, "", false)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if resp.Failed != "th/z.xml" {
+		t.Errorf("failed = %q, want %q", resp.Failed, "th/z.xml")
+	}
+	if resp.TotalReplaced != 0 {
+		t.Errorf("replaced = %d, want 0", resp.TotalReplaced)
+	}
+	if got := readTestFile(t, root, "my/a.xml"); got != "<p>dhamma dhamma</p>" {
+		t.Errorf("a.xml modified: %q", got)
+	}
+}
+
+func TestSearchReplaceRejectsEmptyMatch(t *testing.T) {
+	for _, pattern := range []string{"x*", "a?", "^", "(b|)"} {
+		t.Run(pattern, func(t *testing.T) {
+			root := replaceTree(t)
+
+			body, _ := json.Marshal(ReplaceRequest{Pattern: pattern, Replacement: "Z", Regex: true})
+			rec := serve(handleSearchReplace, http.MethodPost, "/api/search-replace", string(body))
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+			if got := readTestFile(t, root, "my/a.xml"); got != "<p>dhamma dhamma</p>" {
+				t.Errorf("a.xml modified: %q", got)
+			}
+		})
+	}
+}
+
+func TestSearchReplaceHandler(t *testing.T) {
+	root := replaceTree(t)
+
+	rec := serve(handleSearchReplace, http.MethodPost, "/api/search-replace", `{"pattern":"dhamma","replacement":"x","glob":"c.xml"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp ReplaceResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.TotalReplaced != 1 || len(resp.Files) != 1 || resp.Files[0].Path != "th/c.xml" {
+		t.Errorf("response = %+v", resp)
+	}
+	if got := readTestFile(t, root, "th/c.xml"); got != "<p>x</p>" {
+		t.Errorf("c.xml = %q", got)
+	}
+}